@@ -5,33 +5,103 @@ SPDX-License-Identifier: Apache-2.0
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
+// Typed errors so gateways built on top of this chaincode can map failures
+// to the right HTTP status instead of pattern-matching error strings
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotFound     = errors.New("not found")
+)
+
 // TrialChain contract for managing clinical trials
 type TrialChain struct {
 	contractapi.Contract
 }
 
+const (
+	// reservedKeyPrefix marks world-state keys (such as fairnessThresholdKey
+	// and authorizedMSPsKey) that are reserved for chaincode configuration
+	// and must never collide with a client-supplied trial ID
+	reservedKeyPrefix = "~"
+	// fairnessThresholdKey is a reserved world-state key (not a valid
+	// trial ID) holding the configurable fairness threshold
+	fairnessThresholdKey = "~config~fairnessThreshold"
+	// flaggedTrialIndex is the composite-key namespace used to range-scan
+	// trials that breached the fairness threshold
+	flaggedTrialIndex = "flagged"
+	// defaultPHICollection is the private data collection CreateTrialPrivate
+	// writes participant demographic data into, per collections_config.json
+	defaultPHICollection = "participantPHI"
+	// authorizedMSPsKey is a reserved world-state key holding the JSON-encoded
+	// allowlist of MSPs permitted to call UpdateTrialStatus
+	authorizedMSPsKey = "~config~authorizedMSPs"
+)
+
+// collectionMSPs mirrors the member orgs in each private data collection's
+// policy in collections_config.json, keyed by collection name. Fabric itself
+// won't replicate private data to a non-member peer, but a non-member org's
+// peer can still be asked to endorse GetTrialPrivate, so the chaincode must
+// reject it explicitly too.
+var collectionMSPs = map[string][]string{
+	defaultPHICollection: {"Org1MSP", "Org2MSP"},
+}
+
+// isReservedTrialID reports whether trialID collides with the world-state
+// key namespace used for chaincode configuration
+func isReservedTrialID(trialID string) bool {
+	return strings.HasPrefix(trialID, reservedKeyPrefix)
+}
+
 // Trial represents a clinical trial record on the blockchain
 type Trial struct {
 	TrialID        string    `json:"trial_id"`
 	Hash           string    `json:"hash"`
+	ParticipantRoot string   `json:"participant_root"`
 	ParticipantCount int     `json:"participant_count"`
 	MLStatus       string    `json:"ml_status"`
 	FairnessScore  float64   `json:"fairness_score"`
 	Timestamp      time.Time `json:"timestamp"`
 	UploadedBy     string    `json:"uploaded_by"`
 	Metadata       string    `json:"metadata"`
+	PrivateDataHash string   `json:"private_data_hash"`
+	ParticipantLeaves []string `json:"participant_leaves"`
 }
 
-// CreateTrial creates a new trial record on the blockchain
-func (tc *TrialChain) CreateTrial(ctx contractapi.TransactionContextInterface, trialID string, hash string, participantCount int, mlStatus string, fairnessScore float64, uploadedBy string, metadata string) error {
+// TrialHistoryEntry represents a single historical version of a trial as
+// recorded in the blockchain's transaction history
+type TrialHistoryEntry struct {
+	TxID      string    `json:"tx_id"`
+	Timestamp time.Time `json:"timestamp"`
+	IsDelete  bool      `json:"is_delete"`
+	Trial     *Trial    `json:"trial"`
+}
+
+// CreateTrial creates a new trial record on the blockchain. The caller must
+// hold the trial_submitter role; UploadedBy is derived from the caller's
+// identity rather than trusted as a client-supplied parameter.
+func (tc *TrialChain) CreateTrial(ctx contractapi.TransactionContextInterface, trialID string, hash string, participantCount int, mlStatus string, fairnessScore float64, metadata string) error {
+	if err := ctx.GetClientIdentity().AssertAttributeValue("role", "trial_submitter"); err != nil {
+		return fmt.Errorf("%w: caller does not have the trial_submitter role: %v", ErrUnauthorized, err)
+	}
+	if isReservedTrialID(trialID) {
+		return fmt.Errorf("trial ID %s uses the reserved %q prefix", trialID, reservedKeyPrefix)
+	}
+	uploadedBy, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to determine caller identity: %v", err)
+	}
+
 	// Check if trial already exists
 	trialJSON, err := ctx.GetStub().GetState(trialID)
 	if err != nil {
@@ -58,7 +128,69 @@ func (tc *TrialChain) CreateTrial(ctx contractapi.TransactionContextInterface, t
 		return err
 	}
 
-	return ctx.GetStub().PutState(trialID, trialJSON)
+	if err := ctx.GetStub().PutState(trialID, trialJSON); err != nil {
+		return fmt.Errorf("failed to write to world state: %v", err)
+	}
+
+	if err := tc.emitTrialEvent(ctx, "TrialCreated", &trial); err != nil {
+		return err
+	}
+
+	return tc.checkFairnessThreshold(ctx, &trial)
+}
+
+// CreateTrialWithRoot creates a new trial record that commits to a Merkle
+// root over participant records instead of a single opaque hash. The caller
+// must hold the trial_submitter role; UploadedBy is derived from the
+// caller's identity rather than trusted as a client-supplied parameter.
+func (tc *TrialChain) CreateTrialWithRoot(ctx contractapi.TransactionContextInterface, trialID string, hash string, participantRoot string, participantCount int, mlStatus string, fairnessScore float64, metadata string) error {
+	if err := ctx.GetClientIdentity().AssertAttributeValue("role", "trial_submitter"); err != nil {
+		return fmt.Errorf("%w: caller does not have the trial_submitter role: %v", ErrUnauthorized, err)
+	}
+	if isReservedTrialID(trialID) {
+		return fmt.Errorf("trial ID %s uses the reserved %q prefix", trialID, reservedKeyPrefix)
+	}
+	uploadedBy, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to determine caller identity: %v", err)
+	}
+
+	// Check if trial already exists
+	trialJSON, err := ctx.GetStub().GetState(trialID)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if trialJSON != nil {
+		return fmt.Errorf("trial %s already exists", trialID)
+	}
+
+	// Create new trial
+	trial := Trial{
+		TrialID:          trialID,
+		Hash:             hash,
+		ParticipantRoot:  participantRoot,
+		ParticipantCount: participantCount,
+		MLStatus:         mlStatus,
+		FairnessScore:    fairnessScore,
+		Timestamp:        time.Now(),
+		UploadedBy:       uploadedBy,
+		Metadata:         metadata,
+	}
+
+	trialJSON, err = json.Marshal(trial)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.GetStub().PutState(trialID, trialJSON); err != nil {
+		return fmt.Errorf("failed to write to world state: %v", err)
+	}
+
+	if err := tc.emitTrialEvent(ctx, "TrialCreated", &trial); err != nil {
+		return err
+	}
+
+	return tc.checkFairnessThreshold(ctx, &trial)
 }
 
 // GetTrial retrieves a trial from the blockchain
@@ -68,7 +200,7 @@ func (tc *TrialChain) GetTrial(ctx contractapi.TransactionContextInterface, tria
 		return nil, fmt.Errorf("failed to read from world state: %v", err)
 	}
 	if trialJSON == nil {
-		return nil, fmt.Errorf("trial %s does not exist", trialID)
+		return nil, fmt.Errorf("%w: trial %s does not exist", ErrNotFound, trialID)
 	}
 
 	var trial Trial
@@ -80,6 +212,52 @@ func (tc *TrialChain) GetTrial(ctx contractapi.TransactionContextInterface, tria
 	return &trial, nil
 }
 
+// QueryTrialsByFairness returns trials whose fairness score falls within
+// [minScore, maxScore], using the CouchDB index on fairness_score
+func (tc *TrialChain) QueryTrialsByFairness(ctx contractapi.TransactionContextInterface, minScore float64, maxScore float64, pageSize int32, bookmark string) ([]*Trial, string, error) {
+	selector := fmt.Sprintf(`{"selector":{"fairness_score":{"$gte":%f,"$lte":%f}}}`, minScore, maxScore)
+	return tc.queryTrialsWithPagination(ctx, selector, pageSize, bookmark)
+}
+
+// QueryTrialsByUploader returns trials uploaded by a given party, using the
+// CouchDB index on uploaded_by
+func (tc *TrialChain) QueryTrialsByUploader(ctx contractapi.TransactionContextInterface, uploadedBy string, pageSize int32, bookmark string) ([]*Trial, string, error) {
+	selector := fmt.Sprintf(`{"selector":{"uploaded_by":%q}}`, uploadedBy)
+	return tc.queryTrialsWithPagination(ctx, selector, pageSize, bookmark)
+}
+
+// QueryTrials runs an ad-hoc Mongo-style CouchDB selector against the trial
+// state, e.g. {"selector":{"fairness_score":{"$lt":0.7},"ml_status":"completed"}}
+func (tc *TrialChain) QueryTrials(ctx contractapi.TransactionContextInterface, selectorJSON string, pageSize int32, bookmark string) ([]*Trial, string, error) {
+	return tc.queryTrialsWithPagination(ctx, selectorJSON, pageSize, bookmark)
+}
+
+// queryTrialsWithPagination runs a CouchDB rich query and decodes the page
+// of matching trials, returning the bookmark to resume from for the next page
+func (tc *TrialChain) queryTrialsWithPagination(ctx contractapi.TransactionContextInterface, query string, pageSize int32, bookmark string) ([]*Trial, string, error) {
+	resultsIterator, responseMetadata, err := ctx.GetStub().GetQueryResultWithPagination(query, pageSize, bookmark)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to execute rich query: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var trials []*Trial
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, "", err
+		}
+
+		var trial Trial
+		if err := json.Unmarshal(queryResponse.Value, &trial); err != nil {
+			return nil, "", err
+		}
+		trials = append(trials, &trial)
+	}
+
+	return trials, responseMetadata.Bookmark, nil
+}
+
 // VerifyTrial verifies the integrity of a trial by checking its hash
 func (tc *TrialChain) VerifyTrial(ctx contractapi.TransactionContextInterface, trialID string, providedHash string) (bool, error) {
 	trial, err := tc.GetTrial(ctx, trialID)
@@ -90,6 +268,128 @@ func (tc *TrialChain) VerifyTrial(ctx contractapi.TransactionContextInterface, t
 	return trial.Hash == providedHash, nil
 }
 
+// CreateTrialPrivate attaches participant-level PHI to an existing trial.
+// The demographics and consent fields are read from the transaction's
+// transient map (never written to the public ledger or included in a
+// block), written to the default private data collection, and only the
+// resulting hash is stored alongside the public record. The caller must
+// hold the trial_submitter role, same as CreateTrial.
+func (tc *TrialChain) CreateTrialPrivate(ctx contractapi.TransactionContextInterface, trialID string) error {
+	if err := ctx.GetClientIdentity().AssertAttributeValue("role", "trial_submitter"); err != nil {
+		return fmt.Errorf("%w: caller does not have the trial_submitter role: %v", ErrUnauthorized, err)
+	}
+
+	trial, err := tc.GetTrial(ctx, trialID)
+	if err != nil {
+		return err
+	}
+
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("failed to read transient data: %v", err)
+	}
+
+	demographics, ok := transientMap["demographics"]
+	if !ok {
+		return fmt.Errorf("transient field demographics is required")
+	}
+	consent, ok := transientMap["consent"]
+	if !ok {
+		return fmt.Errorf("transient field consent is required")
+	}
+
+	privateData := struct {
+		Demographics json.RawMessage `json:"demographics"`
+		Consent      json.RawMessage `json:"consent"`
+	}{
+		Demographics: demographics,
+		Consent:      consent,
+	}
+	privateJSON, err := json.Marshal(privateData)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.GetStub().PutPrivateData(defaultPHICollection, trialID, privateJSON); err != nil {
+		return fmt.Errorf("failed to write private data: %v", err)
+	}
+
+	hash := sha256.Sum256(privateJSON)
+	trial.PrivateDataHash = hex.EncodeToString(hash[:])
+
+	trialJSON, err := json.Marshal(trial)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(trialID, trialJSON)
+}
+
+// GetTrialPrivate returns a trial's private demographics and consent data
+// from the given collection, gated on the caller's MSP being a member of
+// the private data collection's policy
+func (tc *TrialChain) GetTrialPrivate(ctx contractapi.TransactionContextInterface, trialID string, collection string) (map[string]json.RawMessage, error) {
+	members, ok := collectionMSPs[collection]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not a known private data collection", ErrUnauthorized, collection)
+	}
+
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to determine caller MSP: %v", ErrUnauthorized, err)
+	}
+
+	authorized := false
+	for _, member := range members {
+		if member == mspID {
+			authorized = true
+			break
+		}
+	}
+	if !authorized {
+		return nil, fmt.Errorf("%w: MSP %s is not a member of the %s private data collection", ErrUnauthorized, mspID, collection)
+	}
+
+	privateJSON, err := ctx.GetStub().GetPrivateData(collection, trialID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private data: %v", err)
+	}
+	if privateJSON == nil {
+		return nil, fmt.Errorf("no private data for trial %s in collection %s", trialID, collection)
+	}
+
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(privateJSON, &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// VerifyTrialPrivateHash confirms that the private data currently stored in
+// collection for trialID still matches the hash recorded on the public
+// ledger, so organizations without access to the collection can still
+// verify integrity without seeing the underlying PHI
+func (tc *TrialChain) VerifyTrialPrivateHash(ctx contractapi.TransactionContextInterface, trialID string, collection string) (bool, error) {
+	trial, err := tc.GetTrial(ctx, trialID)
+	if err != nil {
+		return false, err
+	}
+	if trial.PrivateDataHash == "" {
+		return false, fmt.Errorf("trial %s has no private data hash", trialID)
+	}
+
+	hashBytes, err := ctx.GetStub().GetPrivateDataHash(collection, trialID)
+	if err != nil {
+		return false, fmt.Errorf("failed to read private data hash: %v", err)
+	}
+	if hashBytes == nil {
+		return false, fmt.Errorf("no private data hash for trial %s in collection %s", trialID, collection)
+	}
+
+	return hex.EncodeToString(hashBytes) == trial.PrivateDataHash, nil
+}
+
 // GetAllTrials returns all trials in the world state
 func (tc *TrialChain) GetAllTrials(ctx contractapi.TransactionContextInterface) ([]*Trial, error) {
 	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
@@ -116,8 +416,16 @@ func (tc *TrialChain) GetAllTrials(ctx contractapi.TransactionContextInterface)
 	return trials, nil
 }
 
-// UpdateTrialStatus updates the ML status of a trial
+// UpdateTrialStatus updates the ML status of a trial. The caller must hold
+// the ml_operator role and belong to an MSP on the authorized allowlist.
 func (tc *TrialChain) UpdateTrialStatus(ctx contractapi.TransactionContextInterface, trialID string, mlStatus string) error {
+	if err := ctx.GetClientIdentity().AssertAttributeValue("role", "ml_operator"); err != nil {
+		return fmt.Errorf("%w: caller does not have the ml_operator role: %v", ErrUnauthorized, err)
+	}
+	if err := tc.requireAuthorizedMSP(ctx); err != nil {
+		return err
+	}
+
 	trial, err := tc.GetTrial(ctx, trialID)
 	if err != nil {
 		return err
@@ -129,7 +437,396 @@ func (tc *TrialChain) UpdateTrialStatus(ctx contractapi.TransactionContextInterf
 		return err
 	}
 
-	return ctx.GetStub().PutState(trialID, trialJSON)
+	if err := ctx.GetStub().PutState(trialID, trialJSON); err != nil {
+		return fmt.Errorf("failed to write to world state: %v", err)
+	}
+
+	return tc.emitTrialEvent(ctx, "TrialStatusUpdated", trial)
+}
+
+// UpdateFairnessScore updates a trial's fairness score, flagging the trial
+// if the new score falls below the configured fairness threshold. The
+// caller must hold the ml_operator role and belong to an MSP on the
+// authorized allowlist, same as UpdateTrialStatus.
+func (tc *TrialChain) UpdateFairnessScore(ctx contractapi.TransactionContextInterface, trialID string, fairnessScore float64) error {
+	if err := ctx.GetClientIdentity().AssertAttributeValue("role", "ml_operator"); err != nil {
+		return fmt.Errorf("%w: caller does not have the ml_operator role: %v", ErrUnauthorized, err)
+	}
+	if err := tc.requireAuthorizedMSP(ctx); err != nil {
+		return err
+	}
+
+	trial, err := tc.GetTrial(ctx, trialID)
+	if err != nil {
+		return err
+	}
+
+	trial.FairnessScore = fairnessScore
+	trialJSON, err := json.Marshal(trial)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.GetStub().PutState(trialID, trialJSON); err != nil {
+		return fmt.Errorf("failed to write to world state: %v", err)
+	}
+
+	return tc.checkFairnessThreshold(ctx, trial)
+}
+
+// SetAuthorizedMSPs configures the allowlist of MSPs permitted to call
+// UpdateTrialStatus. Callable only by a caller holding the admin role.
+func (tc *TrialChain) SetAuthorizedMSPs(ctx contractapi.TransactionContextInterface, msps []string) error {
+	if err := ctx.GetClientIdentity().AssertAttributeValue("role", "admin"); err != nil {
+		return fmt.Errorf("%w: caller does not have the admin role: %v", ErrUnauthorized, err)
+	}
+
+	data, err := json.Marshal(msps)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(authorizedMSPsKey, data)
+}
+
+// requireAuthorizedMSP returns ErrUnauthorized unless the caller's MSP is on
+// the allowlist configured by SetAuthorizedMSPs
+func (tc *TrialChain) requireAuthorizedMSP(ctx contractapi.TransactionContextInterface) error {
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("%w: failed to determine caller MSP: %v", ErrUnauthorized, err)
+	}
+
+	data, err := ctx.GetStub().GetState(authorizedMSPsKey)
+	if err != nil {
+		return fmt.Errorf("failed to read authorized MSPs: %v", err)
+	}
+
+	var authorizedMSPs []string
+	if data != nil {
+		if err := json.Unmarshal(data, &authorizedMSPs); err != nil {
+			return err
+		}
+	}
+
+	for _, authorized := range authorizedMSPs {
+		if authorized == mspID {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: MSP %s is not authorized to update trial status", ErrUnauthorized, mspID)
+}
+
+// SetFairnessThreshold configures the fairness score below which a trial is
+// flagged and a FairnessThresholdBreached event is emitted. Callable only
+// by a caller holding the admin role.
+func (tc *TrialChain) SetFairnessThreshold(ctx contractapi.TransactionContextInterface, threshold float64) error {
+	if err := ctx.GetClientIdentity().AssertAttributeValue("role", "admin"); err != nil {
+		return fmt.Errorf("%w: caller does not have the admin role: %v", ErrUnauthorized, err)
+	}
+
+	return ctx.GetStub().PutState(fairnessThresholdKey, []byte(strconv.FormatFloat(threshold, 'f', -1, 64)))
+}
+
+// GetFlaggedTrials range-scans the ~flagged~trialID composite-key index and
+// returns every trial that has breached the fairness threshold
+func (tc *TrialChain) GetFlaggedTrials(ctx contractapi.TransactionContextInterface) ([]*Trial, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(flaggedTrialIndex, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to range-scan flagged trials: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var trials []*Trial
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		if len(keyParts) == 0 {
+			continue
+		}
+
+		trial, err := tc.GetTrial(ctx, keyParts[0])
+		if err != nil {
+			return nil, err
+		}
+		trials = append(trials, trial)
+	}
+
+	return trials, nil
+}
+
+// emitTrialEvent sets a compact JSON chaincode event so off-chain ML
+// workers, dashboards, and alerting systems can react without polling
+// GetAllTrials
+func (tc *TrialChain) emitTrialEvent(ctx contractapi.TransactionContextInterface, name string, trial *Trial) error {
+	serverTimestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload := struct {
+		TrialID         string    `json:"trial_id"`
+		UploadedBy      string    `json:"uploaded_by"`
+		MLStatus        string    `json:"ml_status"`
+		FairnessScore   float64   `json:"fairness_score"`
+		ServerTimestamp time.Time `json:"server_timestamp"`
+	}{
+		TrialID:         trial.TrialID,
+		UploadedBy:      trial.UploadedBy,
+		MLStatus:        trial.MLStatus,
+		FairnessScore:   trial.FairnessScore,
+		ServerTimestamp: serverTimestamp,
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().SetEvent(name, payloadJSON)
+}
+
+// txTimestamp returns the transaction's deterministic timestamp as agreed
+// by the endorsing peers, rather than each peer's own wall clock
+func txTimestamp(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	return time.Unix(ts.Seconds, int64(ts.Nanos)), nil
+}
+
+// checkFairnessThreshold flags a trial and emits FairnessThresholdBreached
+// when its fairness score falls below the configured threshold, and clears
+// the flag once a trial's score recovers back above it
+func (tc *TrialChain) checkFairnessThreshold(ctx contractapi.TransactionContextInterface, trial *Trial) error {
+	data, err := ctx.GetStub().GetState(fairnessThresholdKey)
+	if err != nil {
+		return fmt.Errorf("failed to read fairness threshold: %v", err)
+	}
+	if data == nil {
+		return nil
+	}
+
+	threshold, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return fmt.Errorf("failed to parse fairness threshold: %v", err)
+	}
+
+	flagKey, err := ctx.GetStub().CreateCompositeKey(flaggedTrialIndex, []string{trial.TrialID})
+	if err != nil {
+		return err
+	}
+
+	if trial.FairnessScore >= threshold {
+		if err := ctx.GetStub().DelState(flagKey); err != nil {
+			return fmt.Errorf("failed to clear flagged trial index: %v", err)
+		}
+		return nil
+	}
+
+	if err := ctx.GetStub().PutState(flagKey, []byte{0x00}); err != nil {
+		return fmt.Errorf("failed to write flagged trial index: %v", err)
+	}
+
+	serverTimestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload := struct {
+		TrialID         string    `json:"trial_id"`
+		UploadedBy      string    `json:"uploaded_by"`
+		FairnessScore   float64   `json:"fairness_score"`
+		Threshold       float64   `json:"threshold"`
+		ServerTimestamp time.Time `json:"server_timestamp"`
+	}{
+		TrialID:         trial.TrialID,
+		UploadedBy:      trial.UploadedBy,
+		FairnessScore:   trial.FairnessScore,
+		Threshold:       threshold,
+		ServerTimestamp: serverTimestamp,
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().SetEvent("FairnessThresholdBreached", payloadJSON)
+}
+
+// GetTrialHistory returns every historical version of a trial, including
+// deletes, so auditors can see prior ML statuses, fairness scores, and who
+// last modified the record
+func (tc *TrialChain) GetTrialHistory(ctx contractapi.TransactionContextInterface, trialID string) ([]*TrialHistoryEntry, error) {
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(trialID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history for trial %s: %v", trialID, err)
+	}
+	defer resultsIterator.Close()
+
+	var history []*TrialHistoryEntry
+	for resultsIterator.HasNext() {
+		modification, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var trial Trial
+		if len(modification.Value) > 0 {
+			if err := json.Unmarshal(modification.Value, &trial); err != nil {
+				return nil, err
+			}
+		}
+
+		entry := &TrialHistoryEntry{
+			TxID:     modification.TxId,
+			IsDelete: modification.IsDelete,
+			Trial:    &trial,
+		}
+		if modification.Timestamp != nil {
+			entry.Timestamp = time.Unix(modification.Timestamp.Seconds, int64(modification.Timestamp.Nanos))
+		}
+		history = append(history, entry)
+	}
+
+	return history, nil
+}
+
+// GetTrialVersionAt returns the trial exactly as it was written by the given
+// transaction, so regulators can reconstruct what the ML pipeline saw when
+// it made its decision
+func (tc *TrialChain) GetTrialVersionAt(ctx contractapi.TransactionContextInterface, trialID string, txID string) (*Trial, error) {
+	history, err := tc.GetTrialHistory(ctx, trialID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range history {
+		if entry.TxID == txID {
+			if entry.IsDelete {
+				return nil, fmt.Errorf("trial %s was deleted in transaction %s", trialID, txID)
+			}
+			return entry.Trial, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no version of trial %s found for transaction %s", trialID, txID)
+}
+
+// AddParticipantBatch appends the given leaf hashes to the trial's
+// previously committed participant set and recomputes the Merkle root over
+// the full accumulated set, using standard Bitcoin-style pairwise hashing
+// (the last leaf is duplicated when a level has odd length). Participants
+// added by earlier batches are never dropped from the commitment.
+func (tc *TrialChain) AddParticipantBatch(ctx contractapi.TransactionContextInterface, trialID string, leaves []string) error {
+	if len(leaves) == 0 {
+		return fmt.Errorf("leaves must not be empty")
+	}
+
+	trial, err := tc.GetTrial(ctx, trialID)
+	if err != nil {
+		return err
+	}
+	if trial.ParticipantRoot != "" && len(trial.ParticipantLeaves) == 0 {
+		return fmt.Errorf("trial %s already has a participant root set by CreateTrialWithRoot; AddParticipantBatch cannot extend a trial whose leaves were not recorded on-chain", trialID)
+	}
+
+	trial.ParticipantLeaves = append(trial.ParticipantLeaves, leaves...)
+	trial.ParticipantRoot = merkleRoot(trial.ParticipantLeaves)
+	trial.ParticipantCount = len(trial.ParticipantLeaves)
+
+	trialJSON, err := json.Marshal(trial)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.GetStub().PutState(trialID, trialJSON); err != nil {
+		return fmt.Errorf("failed to write to world state: %v", err)
+	}
+
+	event := struct {
+		TrialID          string `json:"trial_id"`
+		ParticipantRoot  string `json:"participant_root"`
+		ParticipantCount int    `json:"participant_count"`
+	}{
+		TrialID:          trialID,
+		ParticipantRoot:  trial.ParticipantRoot,
+		ParticipantCount: trial.ParticipantCount,
+	}
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().SetEvent("ParticipantBatchAdded", eventJSON)
+}
+
+// VerifyParticipantInclusion walks a Merkle proof from leafHash up to the
+// trial's stored participant root. Bit i of index selects the side of the
+// sibling at proof[i]: 0 means the sibling is on the right, 1 means it is on
+// the left.
+func (tc *TrialChain) VerifyParticipantInclusion(ctx contractapi.TransactionContextInterface, trialID string, leafHash string, proof []string, index int) (bool, error) {
+	trial, err := tc.GetTrial(ctx, trialID)
+	if err != nil {
+		return false, err
+	}
+	if trial.ParticipantRoot == "" {
+		return false, fmt.Errorf("trial %s has no participant root", trialID)
+	}
+
+	return verifyMerkleProof(trial.ParticipantRoot, leafHash, proof, index), nil
+}
+
+// verifyMerkleProof walks a Merkle proof from leafHash up to root. Bit i of
+// index selects the side of the sibling at proof[i]: 0 means the sibling is
+// on the right, 1 means it is on the left.
+func verifyMerkleProof(root string, leafHash string, proof []string, index int) bool {
+	current := leafHash
+	for i, sibling := range proof {
+		if (index>>uint(i))&1 == 0 {
+			current = hashPair(current, sibling)
+		} else {
+			current = hashPair(sibling, current)
+		}
+	}
+
+	return current == root
+}
+
+// merkleRoot computes a Bitcoin-style Merkle root over the given leaf
+// hashes, duplicating the last leaf at any level of odd length
+func merkleRoot(leaves []string) string {
+	level := make([]string, len(leaves))
+	copy(level, leaves)
+
+	for len(level) > 1 {
+		if len(level)%2 != 0 {
+			level = append(level, level[len(level)-1])
+		}
+		next := make([]string, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			next = append(next, hashPair(level[i], level[i+1]))
+		}
+		level = next
+	}
+
+	return level[0]
+}
+
+// hashPair returns the hex-encoded SHA-256 digest of two concatenated hashes
+func hashPair(left, right string) string {
+	sum := sha256.Sum256([]byte(left + right))
+	return hex.EncodeToString(sum[:])
 }
 
 func main() {
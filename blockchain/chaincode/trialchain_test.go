@@ -0,0 +1,194 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// fakeStub implements only the shim.ChaincodeStubInterface methods exercised
+// by these tests; every other method panics via the embedded nil interface
+// if a test accidentally reaches it.
+type fakeStub struct {
+	shim.ChaincodeStubInterface
+	state map[string][]byte
+}
+
+func newFakeStub() *fakeStub {
+	return &fakeStub{state: make(map[string][]byte)}
+}
+
+func (s *fakeStub) GetState(key string) ([]byte, error) {
+	return s.state[key], nil
+}
+
+func (s *fakeStub) PutState(key string, value []byte) error {
+	s.state[key] = value
+	return nil
+}
+
+func (s *fakeStub) DelState(key string) error {
+	delete(s.state, key)
+	return nil
+}
+
+// fakeClientIdentity implements cid.ClientIdentity with caller-configurable
+// responses, so tests can drive both the happy and rejection paths of the
+// role/MSP checks without a real MSP or peer.
+type fakeClientIdentity struct {
+	mspID     string
+	id        string
+	assertErr error
+}
+
+func (f *fakeClientIdentity) GetID() (string, error) {
+	return f.id, nil
+}
+
+func (f *fakeClientIdentity) GetMSPID() (string, error) {
+	return f.mspID, nil
+}
+
+func (f *fakeClientIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	return "", false, nil
+}
+
+func (f *fakeClientIdentity) AssertAttributeValue(attrName, attrValue string) error {
+	return f.assertErr
+}
+
+func (f *fakeClientIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return nil, nil
+}
+
+// fakeTransactionContext implements contractapi.TransactionContextInterface
+type fakeTransactionContext struct {
+	stub     *fakeStub
+	identity cid.ClientIdentity
+}
+
+func (c *fakeTransactionContext) GetStub() shim.ChaincodeStubInterface {
+	return c.stub
+}
+
+func (c *fakeTransactionContext) GetClientIdentity() cid.ClientIdentity {
+	return c.identity
+}
+
+func newFakeContext(mspID string, assertErr error) *fakeTransactionContext {
+	return &fakeTransactionContext{
+		stub:     newFakeStub(),
+		identity: &fakeClientIdentity{mspID: mspID, id: "test-identity", assertErr: assertErr},
+	}
+}
+
+func TestMerkleRootAndProofRoundTrip(t *testing.T) {
+	leaves := []string{"a", "b", "c", "d"}
+	root := merkleRoot(leaves)
+
+	ab := hashPair("a", "b")
+	cd := hashPair("c", "d")
+	if root != hashPair(ab, cd) {
+		t.Fatalf("merkleRoot did not pairwise-hash as expected")
+	}
+
+	proof := []string{"d", ab}
+	if !verifyMerkleProof(root, "c", proof, 2) {
+		t.Fatalf("expected proof for leaf c at index 2 to verify")
+	}
+	if verifyMerkleProof(root, "c", proof, 3) {
+		t.Fatalf("expected proof for leaf c to fail against the wrong index")
+	}
+	if verifyMerkleProof(root, "c", proof, 0) {
+		t.Fatalf("expected proof for leaf c to fail against the wrong index")
+	}
+}
+
+func TestMerkleRootAndProofRoundTripOddLeaves(t *testing.T) {
+	// An odd-length level duplicates its last leaf, so the duplicated leaf
+	// is its own sibling in the proof.
+	leaves := []string{"a", "b", "c"}
+	root := merkleRoot(leaves)
+
+	ab := hashPair("a", "b")
+	cc := hashPair("c", "c")
+	if root != hashPair(ab, cc) {
+		t.Fatalf("merkleRoot did not duplicate the last leaf on an odd level")
+	}
+
+	proof := []string{"c", ab}
+	if !verifyMerkleProof(root, "c", proof, 2) {
+		t.Fatalf("expected proof for duplicated leaf c at index 2 to verify")
+	}
+}
+
+func TestCreateTrialRejectsWrongRole(t *testing.T) {
+	ctx := newFakeContext("Org1MSP", errors.New("attribute role not found"))
+	tc := &TrialChain{}
+
+	err := tc.CreateTrial(ctx, "trial-1", "hash", 10, "pending", 0.9, "{}")
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestCreateTrialRejectsReservedID(t *testing.T) {
+	ctx := newFakeContext("Org1MSP", nil)
+	tc := &TrialChain{}
+
+	err := tc.CreateTrial(ctx, fairnessThresholdKey, "hash", 10, "pending", 0.9, "{}")
+	if err == nil {
+		t.Fatalf("expected an error for a trial ID colliding with a reserved config key")
+	}
+}
+
+func TestRequireAuthorizedMSPRejectsUnlistedMSP(t *testing.T) {
+	ctx := newFakeContext("Org3MSP", nil)
+	allowlist, _ := json.Marshal([]string{"Org1MSP", "Org2MSP"})
+	ctx.stub.state[authorizedMSPsKey] = allowlist
+
+	tc := &TrialChain{}
+	err := tc.requireAuthorizedMSP(ctx)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized for an MSP not on the allowlist, got %v", err)
+	}
+}
+
+func TestRequireAuthorizedMSPAcceptsListedMSP(t *testing.T) {
+	ctx := newFakeContext("Org1MSP", nil)
+	allowlist, _ := json.Marshal([]string{"Org1MSP", "Org2MSP"})
+	ctx.stub.state[authorizedMSPsKey] = allowlist
+
+	tc := &TrialChain{}
+	if err := tc.requireAuthorizedMSP(ctx); err != nil {
+		t.Fatalf("expected a listed MSP to be authorized, got %v", err)
+	}
+}
+
+func TestGetTrialPrivateRejectsUnknownCollection(t *testing.T) {
+	ctx := newFakeContext("Org1MSP", nil)
+	tc := &TrialChain{}
+
+	_, err := tc.GetTrialPrivate(ctx, "trial-1", "notARealCollection")
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized for an unknown collection, got %v", err)
+	}
+}
+
+func TestGetTrialPrivateRejectsNonMemberMSP(t *testing.T) {
+	ctx := newFakeContext("Org9MSP", nil)
+	tc := &TrialChain{}
+
+	_, err := tc.GetTrialPrivate(ctx, "trial-1", defaultPHICollection)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized for a non-member MSP, got %v", err)
+	}
+}